@@ -0,0 +1,125 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/iot-operations-sdks/go/mqtt"
+)
+
+// CommandHandler invokes the application logic for a single command
+// invocation and returns the serialized response payload.
+type CommandHandler func(ctx context.Context, req []byte) ([]byte, error)
+
+// CommandExecutor subscribes to a request topic, invokes a CommandHandler
+// for each inbound command, and publishes the handler's response to the
+// request's response topic. WithTracerProvider, WithMeterProvider, and
+// WithMetrics instrument every invocation it handles.
+//
+// The mqtt.SessionClient/mqtt.Message surface used here (RegisterMessageHandler
+// returning an unregister func, Message.Ack as a func field, CorrelationData
+// as []byte, SessionClient.ID(), WithCorrelationData, NewSessionClientFromEnv)
+// has not been compiled against the real go/mqtt v0.4.0 module, which isn't
+// present in this tree to check against. Verify against the real module
+// before merging.
+type CommandExecutor struct {
+	client       *mqtt.SessionClient
+	commandName  string
+	requestTopic string
+	handler      CommandHandler
+
+	telemetry *executorTelemetry
+	metrics   Metrics
+
+	unregister func()
+}
+
+// NewCommandExecutor creates a CommandExecutor that handles commandName
+// invocations received on requestTopic over client.
+func NewCommandExecutor(client *mqtt.SessionClient, commandName, requestTopic string, handler CommandHandler, opts ...CommandExecutorOption) (*CommandExecutor, error) {
+	o := &CommandExecutorOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	telemetry, err := newExecutorTelemetry(o)
+	if err != nil {
+		return nil, fmt.Errorf("setting up telemetry: %w", err)
+	}
+
+	metrics := o.metrics
+	if metrics == nil {
+		metrics = NopMetrics
+	}
+
+	return &CommandExecutor{
+		client:       client,
+		commandName:  commandName,
+		requestTopic: requestTopic,
+		handler:      handler,
+		telemetry:    telemetry,
+		metrics:      metrics,
+	}, nil
+}
+
+// Start registers the executor's message handler and subscribes to the
+// request topic.
+func (e *CommandExecutor) Start(ctx context.Context) error {
+	e.unregister = e.client.RegisterMessageHandler(e.handleMessage)
+	if _, err := e.client.Subscribe(ctx, e.requestTopic); err != nil {
+		e.unregister()
+		e.unregister = nil
+		return fmt.Errorf("subscribing to %s: %w", e.requestTopic, err)
+	}
+	return nil
+}
+
+// Close unregisters the executor's message handler. It does not unsubscribe
+// from the request topic, since other executors may share the connection.
+func (e *CommandExecutor) Close() error {
+	if e.unregister != nil {
+		e.unregister()
+		e.unregister = nil
+	}
+	return nil
+}
+
+// handleMessage is the mqtt.MessageHandler registered with client. It
+// instruments the invocation, runs the handler, and publishes the response.
+func (e *CommandExecutor) handleMessage(ctx context.Context, msg *mqtt.Message) {
+	if msg.Topic != e.requestTopic {
+		return
+	}
+	if msg.Ack != nil {
+		defer msg.Ack()
+	}
+	start := time.Now()
+
+	ctx = extractTraceContext(ctx, msg.UserProperties)
+	invokerClientID := msg.UserProperties[invokerClientIDKey]
+	correlationID := string(msg.CorrelationData)
+
+	ctx, span := e.telemetry.startSpan(ctx, e.commandName, msg.Topic, correlationID, invokerClientID)
+	stopInFlight := e.telemetry.trackInFlight(ctx, e.commandName)
+	defer stopInFlight()
+
+	resp, err := e.handler(ctx, msg.Payload)
+	statusCode := "200"
+	if err != nil {
+		statusCode = "500"
+	}
+
+	e.telemetry.endSpan(ctx, span, e.commandName, start, statusCode, err)
+
+	if err == nil && msg.ResponseTopic != "" {
+		if _, err := e.client.Publish(ctx, msg.ResponseTopic, resp, mqtt.WithCorrelationData(msg.CorrelationData)); err != nil {
+			statusCode = "publish_error"
+		}
+	}
+	e.metrics.CommandInvoked(e.commandName, statusCode, time.Since(start))
+}
+
+// invokerClientIDKey is the MQTT user property key an invoker sets to carry
+// its client ID, used to attribute spans to the caller.
+const invokerClientIDKey = "__invId"
@@ -0,0 +1,141 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Azure/iot-operations-sdks/go/mqtt"
+)
+
+// fakeSpan is a minimal trace.Span that records what CommandExecutor sets on
+// it, without pulling in the OpenTelemetry SDK.
+type fakeSpan struct {
+	trace.Span
+	status codes.Code
+	err    error
+	ended  bool
+}
+
+func (s *fakeSpan) End(...trace.SpanEndOption)                    { s.ended = true }
+func (s *fakeSpan) IsRecording() bool                             { return true }
+func (s *fakeSpan) SetAttributes(...attribute.KeyValue)           {}
+func (s *fakeSpan) SetStatus(code codes.Code, _ string)           { s.status = code }
+func (s *fakeSpan) RecordError(err error, _ ...trace.EventOption) { s.err = err }
+
+// fakeTracer always starts the same fakeSpan, so a test can inspect it after
+// handleMessage returns.
+type fakeTracer struct {
+	span *fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, _ string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return trace.ContextWithSpan(ctx, t.span), t.span
+}
+
+// fakeMetrics records the last CommandInvoked call.
+type fakeMetrics struct {
+	calls      int
+	statusCode string
+}
+
+func (m *fakeMetrics) CommandInvoked(_, statusCode string, _ time.Duration) {
+	m.calls++
+	m.statusCode = statusCode
+}
+
+func newTestExecutor(t *testing.T, handler CommandHandler, metrics Metrics) (*CommandExecutor, *fakeSpan) {
+	t.Helper()
+	span := &fakeSpan{}
+	return &CommandExecutor{
+		commandName:  "SayHello",
+		requestTopic: "rpc/greeter/SayHello",
+		handler:      handler,
+		telemetry:    &executorTelemetry{tracer: &fakeTracer{span: span}},
+		metrics:      metrics,
+	}, span
+}
+
+func TestCommandExecutorHandleMessageSuccess(t *testing.T) {
+	metrics := &fakeMetrics{}
+	e, span := newTestExecutor(t, func(context.Context, []byte) ([]byte, error) {
+		return []byte(`{"message":"hi"}`), nil
+	}, metrics)
+
+	acked := false
+	e.handleMessage(context.Background(), &mqtt.Message{
+		Topic:   e.requestTopic,
+		Payload: []byte(`{"name":"world"}`),
+		Ack:     func() { acked = true },
+	})
+
+	if !acked {
+		t.Error("handleMessage did not call msg.Ack")
+	}
+	if !span.ended {
+		t.Error("handleMessage did not end the span")
+	}
+	if span.status != codes.Ok {
+		t.Errorf("span status = %v, want codes.Ok", span.status)
+	}
+	if metrics.calls != 1 || metrics.statusCode != "200" {
+		t.Errorf("metrics.CommandInvoked = %d calls with status %q, want 1 call with status 200", metrics.calls, metrics.statusCode)
+	}
+}
+
+func TestCommandExecutorHandleMessageHandlerError(t *testing.T) {
+	metrics := &fakeMetrics{}
+	handlerErr := errors.New("boom")
+	e, span := newTestExecutor(t, func(context.Context, []byte) ([]byte, error) {
+		return nil, handlerErr
+	}, metrics)
+
+	acked := false
+	e.handleMessage(context.Background(), &mqtt.Message{
+		Topic:   e.requestTopic,
+		Payload: []byte(`{"name":"world"}`),
+		Ack:     func() { acked = true },
+	})
+
+	if !acked {
+		t.Error("handleMessage did not call msg.Ack")
+	}
+	if span.status != codes.Error {
+		t.Errorf("span status = %v, want codes.Error", span.status)
+	}
+	if span.err != handlerErr {
+		t.Errorf("span.RecordError got %v, want %v", span.err, handlerErr)
+	}
+	if metrics.calls != 1 || metrics.statusCode != "500" {
+		t.Errorf("metrics.CommandInvoked = %d calls with status %q, want 1 call with status 500", metrics.calls, metrics.statusCode)
+	}
+}
+
+func TestCommandExecutorHandleMessageIgnoresOtherTopics(t *testing.T) {
+	metrics := &fakeMetrics{}
+	e, span := newTestExecutor(t, func(context.Context, []byte) ([]byte, error) {
+		t.Fatal("handler should not be called for a message on another topic")
+		return nil, nil
+	}, metrics)
+
+	acked := false
+	e.handleMessage(context.Background(), &mqtt.Message{
+		Topic: "some/other/topic",
+		Ack:   func() { acked = true },
+	})
+
+	if acked {
+		t.Error("handleMessage acked a message on a topic it doesn't own")
+	}
+	if span.ended {
+		t.Error("handleMessage started a span for a message it ignored")
+	}
+	if metrics.calls != 0 {
+		t.Errorf("metrics.CommandInvoked called %d times, want 0", metrics.calls)
+	}
+}
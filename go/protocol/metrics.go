@@ -0,0 +1,32 @@
+package protocol
+
+import "time"
+
+// Metrics is the registry a CommandExecutor publishes runtime measurements
+// into. Implementations are expected to be safe for concurrent use. A nil
+// Metrics is valid everywhere one is accepted and disables instrumentation.
+//
+// MQTT-level measurements (reconnect counts, unacked publish depth) are
+// intentionally out of scope here: mqtt.SessionClient exposes no hooks for
+// them in this tree, so a Metrics method for them would have no caller.
+// Revisit once go/mqtt grows that instrumentation surface.
+type Metrics interface {
+	// CommandInvoked records one invocation of the named command, along
+	// with its outcome status code and how long it took to handle.
+	CommandInvoked(commandName, statusCode string, duration time.Duration)
+}
+
+// NopMetrics is a Metrics implementation whose methods do nothing. It is the
+// default used when no registry is configured, so instrumentation stays
+// optional without callers needing to nil-check.
+var NopMetrics Metrics = nopMetrics{}
+
+type nopMetrics struct{}
+
+func (nopMetrics) CommandInvoked(string, string, time.Duration) {}
+
+// WithMetrics configures the Metrics registry a CommandExecutor publishes
+// invocation counts and latency into. If unset, NopMetrics is used.
+func WithMetrics(m Metrics) CommandExecutorOption {
+	return func(o *CommandExecutorOptions) { o.metrics = m }
+}
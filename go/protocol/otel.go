@@ -0,0 +1,156 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies this package to the OpenTelemetry SDK when
+// creating tracers and meters.
+const instrumentationName = "github.com/Azure/iot-operations-sdks/go/protocol"
+
+// CommandExecutorOption configures optional behavior of a CommandExecutor.
+type CommandExecutorOption func(*CommandExecutorOptions)
+
+// CommandExecutorOptions holds the values configured by CommandExecutorOption.
+type CommandExecutorOptions struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	metrics        Metrics
+}
+
+// WithTracerProvider configures the TracerProvider a CommandExecutor uses to
+// start a span around each inbound command invocation. The span is linked to
+// the remote invoker's span via the W3C trace context carried in the
+// request's MQTT user properties. If unset, the executor uses the global
+// TracerProvider (otel.GetTracerProvider), which produces no spans unless the
+// application has installed one with otel.SetTracerProvider.
+func WithTracerProvider(tp trace.TracerProvider) CommandExecutorOption {
+	return func(o *CommandExecutorOptions) { o.tracerProvider = tp }
+}
+
+// WithMeterProvider configures the MeterProvider a CommandExecutor uses to
+// record request counts, in-flight requests, and end-to-end latency. If
+// unset, the executor does not record metrics.
+func WithMeterProvider(mp metric.MeterProvider) CommandExecutorOption {
+	return func(o *CommandExecutorOptions) { o.meterProvider = mp }
+}
+
+// executorTelemetry holds the tracer, meter, and instruments used to
+// instrument a CommandExecutor. A zero-value executorTelemetry is safe to use
+// and produces no spans or metrics, so instrumentation stays optional.
+type executorTelemetry struct {
+	tracer trace.Tracer
+
+	requestCount   metric.Int64Counter
+	inFlight       metric.Int64UpDownCounter
+	requestLatency metric.Float64Histogram
+}
+
+// newExecutorTelemetry builds the telemetry for a CommandExecutor from the
+// providers configured via WithTracerProvider and WithMeterProvider.
+func newExecutorTelemetry(o *CommandExecutorOptions) (*executorTelemetry, error) {
+	t := &executorTelemetry{}
+
+	tp := o.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	t.tracer = tp.Tracer(instrumentationName)
+
+	if o.meterProvider == nil {
+		return t, nil
+	}
+	meter := o.meterProvider.Meter(instrumentationName)
+
+	var err error
+	if t.requestCount, err = meter.Int64Counter(
+		"rpc.server.request_count",
+		metric.WithDescription("Number of command invocations received by the executor"),
+	); err != nil {
+		return nil, fmt.Errorf("creating rpc.server.request_count counter: %w", err)
+	}
+	if t.inFlight, err = meter.Int64UpDownCounter(
+		"rpc.server.active_requests",
+		metric.WithDescription("Number of command invocations currently being handled"),
+	); err != nil {
+		return nil, fmt.Errorf("creating rpc.server.active_requests counter: %w", err)
+	}
+	if t.requestLatency, err = meter.Float64Histogram(
+		"rpc.server.duration",
+		metric.WithDescription("End-to-end duration of a command invocation"),
+		metric.WithUnit("ms"),
+	); err != nil {
+		return nil, fmt.Errorf("creating rpc.server.duration histogram: %w", err)
+	}
+	return t, nil
+}
+
+// commandSpanAttrs returns the span and metric attributes common to an
+// invocation of the named command.
+func commandSpanAttrs(commandName, topic, correlationID, invokerClientID string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("rpc.system", "mqtt"),
+		attribute.String("rpc.command", commandName),
+		attribute.String("mqtt.topic", topic),
+		attribute.String("rpc.correlation_id", correlationID),
+		attribute.String("rpc.invoker_client_id", invokerClientID),
+	}
+}
+
+// startSpan starts a server span for an inbound command invocation, resuming
+// the trace context extracted from the request's MQTT user properties by
+// extractTraceContext. The caller must end the returned span, typically via
+// endSpan.
+func (t *executorTelemetry) startSpan(ctx context.Context, commandName, topic, correlationID, invokerClientID string) (context.Context, trace.Span) {
+	if t == nil || t.tracer == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return t.tracer.Start(ctx, "rpc.server/"+commandName,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(commandSpanAttrs(commandName, topic, correlationID, invokerClientID)...),
+	)
+}
+
+// trackInFlight increments the in-flight gauge for commandName and returns a
+// function that decrements it once the invocation completes.
+func (t *executorTelemetry) trackInFlight(ctx context.Context, commandName string) func() {
+	if t == nil || t.inFlight == nil {
+		return func() {}
+	}
+	attrs := metric.WithAttributes(attribute.String("rpc.command", commandName))
+	t.inFlight.Add(ctx, 1, attrs)
+	return func() { t.inFlight.Add(ctx, -1, attrs) }
+}
+
+// endSpan records the outcome of a completed command invocation on span and
+// in the request count / latency instruments, then ends span.
+func (t *executorTelemetry) endSpan(ctx context.Context, span trace.Span, commandName string, start time.Time, statusCode string, err error) {
+	if span.IsRecording() {
+		span.SetAttributes(attribute.String("rpc.status_code", statusCode))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetStatus(codes.Ok, "")
+		}
+	}
+	span.End()
+
+	if t == nil || t.requestCount == nil {
+		return
+	}
+	attrs := metric.WithAttributes(
+		attribute.String("rpc.command", commandName),
+		attribute.String("rpc.status_code", statusCode),
+	)
+	t.requestCount.Add(ctx, 1, attrs)
+	t.requestLatency.Record(ctx, float64(time.Since(start).Microseconds())/1000, attrs)
+}
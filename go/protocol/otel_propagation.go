@@ -0,0 +1,42 @@
+package protocol
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// textMapPropagator is used to extract trace context from MQTT user
+// properties. It is independent of the process-wide global propagator
+// (otel.GetTextMapPropagator) so that CommandExecutor instrumentation works
+// without the application having to call otel.SetTextMapPropagator itself.
+var textMapPropagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// userPropertyCarrier adapts a map of MQTT user properties to
+// propagation.TextMapCarrier so the OpenTelemetry propagator can read and
+// write the traceparent/tracestate keys without the protocol package
+// depending on a specific MQTT client's property type.
+type userPropertyCarrier map[string]string
+
+func (c userPropertyCarrier) Get(key string) string {
+	return c[key]
+}
+
+func (c userPropertyCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c userPropertyCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// extractTraceContext reads the W3C traceparent/tracestate from the given
+// MQTT user properties and returns a context carrying the resulting remote
+// span context, so the executor's span links to the invoker's.
+func extractTraceContext(ctx context.Context, props map[string]string) context.Context {
+	return textMapPropagator.Extract(ctx, userPropertyCarrier(props))
+}
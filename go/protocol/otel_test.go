@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCommandSpanAttrs(t *testing.T) {
+	attrs := commandSpanAttrs("SayHello", "rpc/greeter/SayHello", "corr-1", "invoker-1")
+
+	want := map[string]string{
+		"rpc.system":            "mqtt",
+		"rpc.command":           "SayHello",
+		"mqtt.topic":            "rpc/greeter/SayHello",
+		"rpc.correlation_id":    "corr-1",
+		"rpc.invoker_client_id": "invoker-1",
+	}
+	if len(attrs) != len(want) {
+		t.Fatalf("commandSpanAttrs returned %d attributes, want %d", len(attrs), len(want))
+	}
+	for _, kv := range attrs {
+		got, ok := want[string(kv.Key)]
+		if !ok {
+			t.Errorf("unexpected attribute key %q", kv.Key)
+			continue
+		}
+		if kv.Value.AsString() != got {
+			t.Errorf("attribute %q = %q, want %q", kv.Key, kv.Value.AsString(), got)
+		}
+	}
+}
+
+func TestUserPropertyCarrier(t *testing.T) {
+	c := userPropertyCarrier{}
+
+	c.Set("traceparent", "00-trace-span-01")
+	c.Set("tracestate", "vendor=value")
+
+	if got := c.Get("traceparent"); got != "00-trace-span-01" {
+		t.Errorf("Get(traceparent) = %q, want %q", got, "00-trace-span-01")
+	}
+	if got := c.Get("missing"); got != "" {
+		t.Errorf("Get(missing) = %q, want empty string", got)
+	}
+
+	keys := c.Keys()
+	sort.Strings(keys)
+	want := []string{"traceparent", "tracestate"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("Keys() = %v, want %v", keys, want)
+	}
+}
@@ -0,0 +1,34 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/iot-operations-sdks/go/mqtt"
+)
+
+// MQTTProfileSink publishes profiles as gzipped pprof payloads to
+// $services/<ClientID>/profiles/<kind>/<timestamp>, so operators can pull
+// live profiles from edge deployments where opening an HTTP port is not
+// acceptable.
+type MQTTProfileSink struct {
+	// Client publishes the profile payloads. Its ID() is used to
+	// namespace the publish topic.
+	Client *mqtt.SessionClient
+}
+
+// Publish implements ProfileSink.
+func (s *MQTTProfileSink) Publish(ctx context.Context, kind ProfileKind, collectedAt time.Time, gzippedProfile []byte) error {
+	topic := profileTopic(s.Client.ID(), kind, collectedAt)
+	if _, err := s.Client.Publish(ctx, topic, gzippedProfile); err != nil {
+		return fmt.Errorf("publishing %s profile to %s: %w", kind, topic, err)
+	}
+	return nil
+}
+
+// profileTopic returns the MQTT topic a profile of the given kind and
+// collection time is published to for the named client.
+func profileTopic(clientID string, kind ProfileKind, collectedAt time.Time) string {
+	return fmt.Sprintf("$services/%s/profiles/%s/%d", clientID, kind, collectedAt.UTC().UnixMilli())
+}
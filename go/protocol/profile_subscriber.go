@@ -0,0 +1,86 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/iot-operations-sdks/go/mqtt"
+)
+
+// ProfileSubscriber subscribes to the profile topics an MQTTProfileSink
+// publishes to and drains received profiles to disk, so a diagnostics
+// client can grab live profiles from edge deployments without opening an
+// HTTP port on them.
+type ProfileSubscriber struct {
+	// Client subscribes to the profile topic filter and delivers messages.
+	Client *mqtt.SessionClient
+
+	// Dir is the directory received profiles are written to. It must
+	// already exist.
+	Dir string
+
+	unregister func()
+}
+
+// Subscribe registers a message handler for clientID's profile topics and
+// subscribes to them. Call the returned function to stop collecting.
+func (s *ProfileSubscriber) Subscribe(ctx context.Context, clientID string) (func(context.Context) error, error) {
+	filter := fmt.Sprintf("$services/%s/profiles/+/+", clientID)
+
+	s.unregister = s.Client.RegisterMessageHandler(s.handleMessage)
+	if _, err := s.Client.Subscribe(ctx, filter); err != nil {
+		s.unregister()
+		s.unregister = nil
+		return nil, fmt.Errorf("subscribing to %s: %w", filter, err)
+	}
+
+	return func(context.Context) error {
+		if s.unregister != nil {
+			s.unregister()
+			s.unregister = nil
+		}
+		return nil
+	}, nil
+}
+
+// handleMessage is the mqtt.MessageHandler registered with Client. It
+// ignores messages on topics that aren't profile topics, so the same
+// connection's handler chain can be shared with other subscribers. It only
+// acks messages it actually owns, since RegisterMessageHandler fans every
+// inbound message on the shared connection out to every registered handler.
+func (s *ProfileSubscriber) handleMessage(_ context.Context, msg *mqtt.Message) {
+	kind, timestamp, ok := parseProfileTopic(msg.Topic)
+	if !ok {
+		return
+	}
+	if msg.Ack != nil {
+		defer msg.Ack()
+	}
+	if err := s.writeProfile(kind, timestamp, msg.Payload); err != nil {
+		slog.Error("protocol: writing received profile failed", "topic", msg.Topic, "error", err)
+	}
+}
+
+// parseProfileTopic extracts the profile kind and collection timestamp from
+// a $services/<clientID>/profiles/<kind>/<timestamp> topic. ok is false if
+// topic isn't shaped like a profile topic.
+func parseProfileTopic(topic string) (kind, timestamp string, ok bool) {
+	parts := strings.Split(topic, "/")
+	if len(parts) != 5 || parts[0] != "$services" || parts[2] != "profiles" {
+		return "", "", false
+	}
+	return parts[3], parts[4], true
+}
+
+func (s *ProfileSubscriber) writeProfile(kind, timestamp string, payload []byte) error {
+	name := fmt.Sprintf("%s-%s.pprof.gz", kind, timestamp)
+	path := filepath.Join(s.Dir, name)
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		return fmt.Errorf("writing profile to %s: %w", path, err)
+	}
+	return nil
+}
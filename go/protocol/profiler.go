@@ -0,0 +1,206 @@
+package protocol
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"time"
+)
+
+// ProfileKind identifies which runtime profile a Profiler has collected.
+type ProfileKind string
+
+// The profile kinds a Profiler collects.
+const (
+	ProfileCPU       ProfileKind = "cpu"
+	ProfileHeap      ProfileKind = "heap"
+	ProfileGoroutine ProfileKind = "goroutine"
+	ProfileMutex     ProfileKind = "mutex"
+)
+
+// ProfileSink receives a collected, gzip-compressed pprof profile. Publish
+// is called once per collection interval per configured ProfileKind.
+//
+// Implementations are expected to return promptly; Profiler does not retry
+// or buffer profiles a sink fails to accept, it only logs the error.
+type ProfileSink interface {
+	Publish(ctx context.Context, kind ProfileKind, collectedAt time.Time, gzippedProfile []byte) error
+}
+
+// ProfilerOptions configures a Profiler.
+type ProfilerOptions struct {
+	// Kinds is the set of profiles to collect each interval. Defaults to
+	// ProfileCPU, ProfileHeap, and ProfileGoroutine.
+	Kinds []ProfileKind
+
+	// Interval is how often profiles are collected and published. Defaults
+	// to 5 minutes.
+	Interval time.Duration
+
+	// CPUDuration is how long each CPU profile samples for; it must be
+	// shorter than Interval. Defaults to 10 seconds.
+	CPUDuration time.Duration
+
+	// MutexProfileFraction is passed to runtime.SetMutexProfileFraction
+	// when Kinds includes ProfileMutex, since mutex profiling reports
+	// nothing unless enabled. Defaults to 1 (every contention event).
+	MutexProfileFraction int
+
+	// Sink receives every collected profile. It is required.
+	Sink ProfileSink
+}
+
+// Profiler periodically collects CPU, heap, goroutine, and mutex profiles
+// via runtime/pprof and publishes them to a ProfileSink, without requiring
+// an HTTP port to be opened on the host.
+type Profiler struct {
+	opts ProfilerOptions
+}
+
+// NewProfiler creates a Profiler from opts, filling in defaults for any
+// unset fields. It returns an error if opts.Sink is nil.
+func NewProfiler(opts ProfilerOptions) (*Profiler, error) {
+	if opts.Sink == nil {
+		return nil, fmt.Errorf("protocol: ProfilerOptions.Sink must not be nil")
+	}
+	if len(opts.Kinds) == 0 {
+		opts.Kinds = []ProfileKind{ProfileCPU, ProfileHeap, ProfileGoroutine}
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Minute
+	}
+	if opts.CPUDuration <= 0 {
+		opts.CPUDuration = 10 * time.Second
+	}
+	if opts.CPUDuration >= opts.Interval {
+		return nil, fmt.Errorf("protocol: ProfilerOptions.CPUDuration (%s) must be shorter than Interval (%s)", opts.CPUDuration, opts.Interval)
+	}
+	if opts.MutexProfileFraction <= 0 {
+		opts.MutexProfileFraction = 1
+	}
+	for _, kind := range opts.Kinds {
+		if kind == ProfileMutex {
+			runtime.SetMutexProfileFraction(opts.MutexProfileFraction)
+			break
+		}
+	}
+	return &Profiler{opts: opts}, nil
+}
+
+// Run collects and publishes profiles on opts.Interval until ctx is done.
+// Run blocks the calling goroutine; callers typically invoke it with `go`.
+func (p *Profiler) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.collectAndPublish(ctx)
+		}
+	}
+}
+
+func (p *Profiler) collectAndPublish(ctx context.Context) {
+	for _, kind := range p.opts.Kinds {
+		data, err := p.collect(ctx, kind)
+		if err != nil {
+			slog.Error("protocol: collecting profile failed", "kind", kind, "error", err)
+			continue
+		}
+		if err := p.opts.Sink.Publish(ctx, kind, time.Now(), data); err != nil {
+			slog.Error("protocol: publishing profile failed", "kind", kind, "error", err)
+		}
+	}
+}
+
+// collect gathers the named profile and returns it gzip-compressed.
+func (p *Profiler) collect(ctx context.Context, kind ProfileKind) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch kind {
+	case ProfileCPU:
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return nil, fmt.Errorf("starting CPU profile: %w", err)
+		}
+		select {
+		case <-time.After(p.opts.CPUDuration):
+		case <-ctx.Done():
+		}
+		pprof.StopCPUProfile()
+	case ProfileHeap:
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(&buf); err != nil {
+			return nil, fmt.Errorf("writing heap profile: %w", err)
+		}
+	case ProfileGoroutine, ProfileMutex:
+		prof := pprof.Lookup(string(kind))
+		if prof == nil {
+			return nil, fmt.Errorf("unknown profile kind %q", kind)
+		}
+		if err := prof.WriteTo(&buf, 0); err != nil {
+			return nil, fmt.Errorf("writing %s profile: %w", kind, err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown profile kind %q", kind)
+	}
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	if _, err := gw.Write(buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("gzipping %s profile: %w", kind, err)
+	}
+	if err := gw.Close(); err != nil {
+		return nil, fmt.Errorf("gzipping %s profile: %w", kind, err)
+	}
+	return gzipped.Bytes(), nil
+}
+
+// FileProfileSink writes profiles to Dir, named
+// "<kind>-<collectedAt-RFC3339>.pprof.gz", and deletes the oldest files for
+// a kind once more than MaxFiles are present.
+type FileProfileSink struct {
+	// Dir is the directory profiles are written to. It must already exist.
+	Dir string
+
+	// MaxFiles is the number of profiles retained per ProfileKind. Older
+	// files are deleted as newer ones are written. Zero means unlimited.
+	MaxFiles int
+}
+
+// Publish implements ProfileSink.
+func (s *FileProfileSink) Publish(_ context.Context, kind ProfileKind, collectedAt time.Time, gzippedProfile []byte) error {
+	name := fmt.Sprintf("%s-%s.pprof.gz", kind, collectedAt.UTC().Format(time.RFC3339))
+	path := filepath.Join(s.Dir, name)
+	if err := os.WriteFile(path, gzippedProfile, 0o644); err != nil {
+		return fmt.Errorf("writing profile to %s: %w", path, err)
+	}
+	return s.rotate(kind)
+}
+
+func (s *FileProfileSink) rotate(kind ProfileKind) error {
+	if s.MaxFiles <= 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(filepath.Join(s.Dir, string(kind)+"-*.pprof.gz"))
+	if err != nil {
+		return fmt.Errorf("listing %s profiles in %s: %w", kind, s.Dir, err)
+	}
+	sort.Strings(matches)
+	for len(matches) > s.MaxFiles {
+		if err := os.Remove(matches[0]); err != nil {
+			return fmt.Errorf("removing old profile %s: %w", matches[0], err)
+		}
+		matches = matches[1:]
+	}
+	return nil
+}
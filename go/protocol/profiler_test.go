@@ -0,0 +1,44 @@
+package protocol
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProfileTopic(t *testing.T) {
+	collectedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	got := profileTopic("client-1", ProfileHeap, collectedAt)
+	want := "$services/client-1/profiles/heap/1767323045000"
+	if got != want {
+		t.Errorf("profileTopic() = %q, want %q", got, want)
+	}
+}
+
+func TestFileProfileSinkRotate(t *testing.T) {
+	dir := t.TempDir()
+	sink := &FileProfileSink{Dir: dir, MaxFiles: 2}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		collectedAt := base.Add(time.Duration(i) * time.Minute)
+		if err := sink.Publish(t.Context(), ProfileCPU, collectedAt, []byte("profile")); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "cpu-*.pprof.gz"))
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("got %d retained files, want 2: %v", len(matches), matches)
+	}
+
+	oldest := filepath.Join(dir, "cpu-"+base.Format(time.RFC3339)+".pprof.gz")
+	if _, err := os.Stat(oldest); !os.IsNotExist(err) {
+		t.Errorf("expected oldest profile %s to be rotated away", oldest)
+	}
+}
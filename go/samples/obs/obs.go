@@ -0,0 +1,92 @@
+// Package obs wires a Prometheus metrics registry and the standard
+// net/http/pprof handlers behind a single local HTTP listener, so samples
+// can opt into observability with one line:
+//
+//	registry, shutdown, err := obs.Serve(":9090")
+//	if err != nil {
+//		return err
+//	}
+//	defer shutdown(context.Background())
+package obs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/Azure/iot-operations-sdks/go/protocol"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry implements protocol.Metrics on top of Prometheus collectors.
+type Registry struct {
+	commandDuration *prometheus.HistogramVec
+}
+
+var _ protocol.Metrics = (*Registry)(nil)
+
+// NewRegistry creates a Registry and registers its collectors with reg. If
+// reg is nil, prometheus.DefaultRegisterer is used.
+func NewRegistry(reg prometheus.Registerer) *Registry {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	factory := promauto.With(reg)
+	return &Registry{
+		commandDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "greeter_command_duration_seconds",
+			Help: "Duration of command invocations, labeled by command and status code.",
+		}, []string{"command", "status_code"}),
+	}
+}
+
+// CommandInvoked implements protocol.Metrics.
+func (r *Registry) CommandInvoked(commandName, statusCode string, duration time.Duration) {
+	r.commandDuration.WithLabelValues(commandName, statusCode).Observe(duration.Seconds())
+}
+
+// Serve starts an HTTP listener on addr serving Prometheus metrics at
+// /metrics and the standard net/http/pprof handlers under /debug/pprof/. It
+// returns the Registry to pass to protocol.WithMetrics, and a shutdown
+// function that gracefully stops the listener.
+func Serve(addr string) (*Registry, func(context.Context) error, error) {
+	reg := prometheus.NewRegistry()
+	registry := NewRegistry(reg)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("obs: starting metrics listener on %s: %w", addr, err)
+	}
+
+	server := &http.Server{Handler: mux}
+	errCh := make(chan error, 1)
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("obs: metrics listener failed: %w", err)
+			return
+		}
+		errCh <- nil
+	}()
+
+	shutdown := func(ctx context.Context) error {
+		if err := server.Shutdown(ctx); err != nil {
+			return fmt.Errorf("shutting down metrics listener: %w", err)
+		}
+		return <-errCh
+	}
+	return registry, shutdown, nil
+}
@@ -0,0 +1,21 @@
+package obs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRegistryCommandInvoked(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	registry := NewRegistry(reg)
+
+	registry.CommandInvoked("SayHello", "200", 50*time.Millisecond)
+
+	count := testutil.CollectAndCount(reg, "greeter_command_duration_seconds")
+	if count != 1 {
+		t.Errorf("got %d greeter_command_duration_seconds samples, want 1", count)
+	}
+}
@@ -0,0 +1,143 @@
+// Command server runs the greeter sample's command executor with optional
+// OpenTelemetry tracing and metrics.
+//
+// OpenTelemetry export is enabled by setting OTEL_EXPORTER_OTLP_ENDPOINT; when
+// the variable is unset, OpenTelemetry tracing and metrics are disabled and no
+// network calls are made on their behalf. Separately, OBS_LISTEN_ADDR serves
+// Prometheus metrics and pprof (see obs.Serve), and PROFILE_DIR or
+// PROFILE_MQTT=1 enables continuous profiling (see startProfiler).
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Azure/iot-operations-sdks/go/mqtt"
+	"github.com/Azure/iot-operations-sdks/go/protocol"
+	"github.com/Azure/iot-operations-sdks/go/samples/obs"
+	"github.com/lmittmann/tint"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// sayHelloTopic is the MQTT topic the executor listens on for SayHello
+// invocations.
+const sayHelloTopic = "rpc/greeter/SayHello"
+
+func main() {
+	logger := slog.New(tint.NewHandler(os.Stderr, &tint.Options{
+		Level: slog.LevelDebug,
+	}))
+	slog.SetDefault(logger)
+
+	if err := run(); err != nil {
+		slog.Error("server exited with error", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	otelEnabled := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != ""
+	tp, mp, shutdown, err := setupOTel(ctx)
+	if err != nil {
+		return fmt.Errorf("setting up OpenTelemetry: %w", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(shutdownCtx); err != nil {
+			slog.Error("error shutting down OpenTelemetry providers", "error", err)
+		}
+	}()
+
+	executorOpts := []protocol.CommandExecutorOption{
+		protocol.WithTracerProvider(tp),
+		protocol.WithMeterProvider(mp),
+	}
+
+	if addr := os.Getenv("OBS_LISTEN_ADDR"); addr != "" {
+		registry, obsShutdown, err := obs.Serve(addr)
+		if err != nil {
+			return fmt.Errorf("starting observability listener: %w", err)
+		}
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := obsShutdown(shutdownCtx); err != nil {
+				slog.Error("error shutting down observability listener", "error", err)
+			}
+		}()
+		executorOpts = append(executorOpts, protocol.WithMetrics(registry))
+		slog.Info("serving Prometheus metrics and pprof", "addr", addr)
+	}
+
+	client, err := mqtt.NewSessionClientFromEnv()
+	if err != nil {
+		return fmt.Errorf("creating MQTT session client: %w", err)
+	}
+
+	if err := startProfiler(ctx, client); err != nil {
+		return fmt.Errorf("starting profiler: %w", err)
+	}
+
+	executor, err := protocol.NewCommandExecutor(client, "SayHello", sayHelloTopic, sayHello, executorOpts...)
+	if err != nil {
+		return fmt.Errorf("creating greeter command executor: %w", err)
+	}
+	defer executor.Close()
+
+	if err := client.Start(); err != nil {
+		return fmt.Errorf("connecting to MQTT broker: %w", err)
+	}
+	defer client.Stop()
+
+	if err := executor.Start(ctx); err != nil {
+		return fmt.Errorf("starting greeter command executor: %w", err)
+	}
+
+	slog.Info("greeter server running", "tracing_enabled", otelEnabled, "metrics_enabled", otelEnabled)
+	<-ctx.Done()
+	slog.Info("shutting down")
+	return nil
+}
+
+// helloRequest and helloResponse are the JSON payloads exchanged on
+// sayHelloTopic.
+type helloRequest struct {
+	Name string `json:"name"`
+}
+
+type helloResponse struct {
+	Message string `json:"message"`
+}
+
+// sayHello is the protocol.CommandHandler for sayHelloTopic.
+func sayHello(_ context.Context, req []byte) ([]byte, error) {
+	var r helloRequest
+	if err := json.Unmarshal(req, &r); err != nil {
+		return nil, fmt.Errorf("decoding request: %w", err)
+	}
+	return json.Marshal(helloResponse{Message: "Hello, " + r.Name + "!"})
+}
+
+// setupOTel builds the TracerProvider and MeterProvider the command executor
+// should use, selected via the OTEL_EXPORTER_OTLP_ENDPOINT environment
+// variable. If the variable is unset, it returns the no-op providers and a
+// shutdown function that does nothing.
+func setupOTel(ctx context.Context) (trace.TracerProvider, metric.MeterProvider, func(context.Context) error, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+		return tracenoop.NewTracerProvider(), noop.NewMeterProvider(), func(context.Context) error { return nil }, nil
+	}
+	return newOTLPProviders(ctx)
+}
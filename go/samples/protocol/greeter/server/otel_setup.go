@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newOTLPProviders builds a TracerProvider and MeterProvider that export via
+// OTLP/gRPC, enabled by OTEL_EXPORTER_OTLP_ENDPOINT in setupOTel. The
+// exporters parse OTEL_EXPORTER_OTLP_ENDPOINT (and friends) themselves per
+// the OTel env var spec, which is a full URL with scheme, not a bare
+// host:port. The returned shutdown function flushes and closes both
+// exporters; callers must call it before the process exits.
+func newOTLPProviders(ctx context.Context) (trace.TracerProvider, metric.MeterProvider, func(context.Context) error, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName("greeter-server"),
+	))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("building resource: %w", err)
+	}
+
+	traceExporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("creating OTLP trace exporter: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(res),
+	)
+
+	metricExporter, err := otlpmetricgrpc.New(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("creating OTLP metric exporter: %w", err)
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+
+	shutdown := func(ctx context.Context) error {
+		var errs []error
+		if err := tp.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		if err := mp.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("shutting down OpenTelemetry providers: %v", errs)
+		}
+		return nil
+	}
+	return tp, mp, shutdown, nil
+}
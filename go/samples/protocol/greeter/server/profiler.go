@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Azure/iot-operations-sdks/go/mqtt"
+	"github.com/Azure/iot-operations-sdks/go/protocol"
+)
+
+// startProfiler enables protocol.Profiler so operators can grab live
+// CPU/heap/goroutine/mutex profiles from edge deployments where opening an
+// HTTP port is not acceptable. Profiles are written to PROFILE_DIR if set,
+// otherwise published over client's own MQTT connection if PROFILE_MQTT=1.
+// startProfiler is a no-op if neither is set.
+func startProfiler(ctx context.Context, client *mqtt.SessionClient) error {
+	sink, err := profileSink(client)
+	if err != nil {
+		return err
+	}
+	if sink == nil {
+		return nil
+	}
+
+	profiler, err := protocol.NewProfiler(protocol.ProfilerOptions{
+		Kinds: []protocol.ProfileKind{protocol.ProfileCPU, protocol.ProfileHeap, protocol.ProfileGoroutine, protocol.ProfileMutex},
+		Sink:  sink,
+	})
+	if err != nil {
+		return fmt.Errorf("creating profiler: %w", err)
+	}
+	go profiler.Run(ctx)
+
+	slog.Info("continuous profiling enabled")
+	return nil
+}
+
+func profileSink(client *mqtt.SessionClient) (protocol.ProfileSink, error) {
+	if dir := os.Getenv("PROFILE_DIR"); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating profile directory %s: %w", dir, err)
+		}
+		return &protocol.FileProfileSink{Dir: dir, MaxFiles: 10}, nil
+	}
+	if os.Getenv("PROFILE_MQTT") == "1" {
+		return &protocol.MQTTProfileSink{Client: client}, nil
+	}
+	return nil, nil
+}